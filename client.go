@@ -1,19 +1,44 @@
 package tfe
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math"
+	"math/rand"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
 )
 
 const (
-	// DefaultBaseURL is the default base url to reach Terraform Enterprise
-	DefaultBaseURL = "https://app.terraform.io"
+	// DefaultHost is the default host to discover Terraform Enterprise
+	// services against.
+	DefaultHost = "app.terraform.io"
+
+	// defaultUserAgent is sent on every request unless Client.UserAgent is set.
+	defaultUserAgent = "terraform-enterprise-go"
+
+	// defaultRequestsPerSecond matches the rate limit Terraform Enterprise
+	// documents for the v2 API.
+	defaultRequestsPerSecond = 30
+
+	// maxRetries is the number of times a request is retried after a
+	// retryable (429 or 5xx) response before giving up.
+	maxRetries = 5
+
+	// baseRetryDelay is the starting point for exponential backoff between
+	// retries when the server doesn't send a Retry-After header.
+	baseRetryDelay = 500 * time.Millisecond
 )
 
 // Error Types
@@ -25,110 +50,130 @@ var (
 	ErrBadStatus            = errors.New("Unrecognized status code")
 )
 
-type PaginatedResponse struct {
-	Meta MetaInfo `json:"meta"`
-}
-
-type MetaInfo struct {
-	Pagination PaginationInfo `json:"pagination"`
-}
-
-type PaginationInfo struct {
-	CurrentPage int `json:"current-page"`
-	NextPage    int `json:"next-page"`
-	TotalPages  int `json:"total-pages"`
-}
-
 // Client exposes an API for communicating with Terraform Enterprise
 type Client struct {
 	// AtlasToken is the token used to authenticate with Terraform Enterprise,
 	// you can generate one from the Terraform Enterprise UI
 	AtlasToken string
 
-	// BaseURL is the base used for all api calls.  If you are using
-	// Terraform Enterprise SaaS, you can set this to DefaultBaseURL
-	BaseURL string
+	// Host is the server to discover and make all api calls against. If
+	// you are using Terraform Enterprise SaaS, you can set this to
+	// DefaultHost. The API's actual base path is resolved lazily via
+	// service discovery (see Discover) rather than hardcoded.
+	Host string
+
+	// HTTPClient is the transport used for all requests. Defaults to
+	// http.DefaultClient; override it to plug in custom timeouts, TLS
+	// config, or instrumentation.
+	HTTPClient *http.Client
+
+	// UserAgent is sent on every API and service discovery request.
+	// Defaults to defaultUserAgent. State downloads and log fetches do not
+	// set it.
+	UserAgent string
+
+	// Headers are merged into every API request, after the client's own
+	// Authorization, Content-Type, and User-Agent headers are set. They are
+	// not sent on service discovery, state download, or log fetch requests.
+	Headers http.Header
+
+	limiterOnce sync.Once
+	limiter     *rate.Limiter
+
+	discoveryMu    sync.Mutex
+	discoveryCache map[string]discoveryDocument
 }
 
 // New creates and returns a new Terraform Enterprise client
-func New(atlasToken string, baseURL string) *Client {
+func New(atlasToken string, host string) *Client {
 	return &Client{
 		AtlasToken: atlasToken,
-		BaseURL:    baseURL,
+		Host:       host,
+		HTTPClient: http.DefaultClient,
+		UserAgent:  defaultUserAgent,
+		limiter:    rate.NewLimiter(rate.Limit(defaultRequestsPerSecond), defaultRequestsPerSecond),
 	}
 }
 
-// ListOrganizations lists all organizations your token can access
-func (c *Client) ListOrganizations() ([]Organization, error) {
-	path := "/api/v2/organizations"
-	orgs := []Organization{}
+// OrganizationList is a single page of organizations, along with the
+// pagination metadata needed to fetch the next one.
+type OrganizationList struct {
+	Items      []Organization
+	Pagination *Pagination
+}
+
+// ListOrganizations lists a single page of organizations your token can
+// access. Use ListOptions.PageNumber to page through the full set.
+func (c *Client) ListOrganizations(ctx context.Context, opts ListOptions) (*OrganizationList, error) {
+	q, err := addQuery(opts)
+	if err != nil {
+		return nil, err
+	}
 
 	type wrapper struct {
-		PaginatedResponse
+		paginatedResponse
 		Data []Organization `json:"data"`
 	}
 
 	var resp wrapper
-	if err := c.do("GET", path, nil, nil, &resp); err != nil {
-		return []Organization{}, err
+	if err := c.do(ctx, "GET", "/organizations", nil, q, &resp); err != nil {
+		return nil, err
 	}
-	orgs = append(orgs, resp.Data...)
 
-	for resp.Meta.Pagination.CurrentPage < resp.Meta.Pagination.TotalPages {
-		q := url.Values{}
-		q.Add("page[number]", strconv.Itoa(resp.Meta.Pagination.CurrentPage+1))
-		if err := c.do("GET", path, nil, nil, &resp); err != nil {
-			return []Organization{}, err
-		}
-		orgs = append(orgs, resp.Data...)
-	}
-	return orgs, nil
+	return &OrganizationList{
+		Items:      resp.Data,
+		Pagination: &resp.Meta.Pagination,
+	}, nil
 }
 
-// ListWorkspaces lists all workspaces for a given organization
-func (c *Client) ListWorkspaces(organization string) ([]Workspace, error) {
-	path := fmt.Sprintf("/api/v2/organizations/%s/workspaces", organization)
-	workspaces := []Workspace{}
+// WorkspaceList is a single page of workspaces, along with the pagination
+// metadata needed to fetch the next one.
+type WorkspaceList struct {
+	Items      []Workspace
+	Pagination *Pagination
+}
+
+// ListWorkspaces lists a single page of workspaces for a given
+// organization. Use ListOptions.PageNumber to page through the full set,
+// or WorkspacesService.Iter to stream every page.
+func (c *Client) ListWorkspaces(ctx context.Context, organization string, opts ListOptions) (*WorkspaceList, error) {
+	q, err := addQuery(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	path := fmt.Sprintf("/organizations/%s/workspaces", organization)
 
 	type wrapper struct {
-		PaginatedResponse
+		paginatedResponse
 		Data []Workspace `json:"data"`
 	}
 
 	var resp wrapper
-	if err := c.do("GET", path, nil, nil, &resp); err != nil {
-		if err == ErrNotFound {
-			return []Workspace{}, ErrWorkspaceNotFound
+	if err := c.do(ctx, "GET", path, nil, q, &resp); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, ErrWorkspaceNotFound
 		}
-		return []Workspace{}, err
+		return nil, err
 	}
-	workspaces = append(workspaces, resp.Data...)
 
-	for resp.Meta.Pagination.CurrentPage < resp.Meta.Pagination.TotalPages {
-		q := url.Values{}
-		q.Add("page[number]", strconv.Itoa(resp.Meta.Pagination.CurrentPage+1))
-		if err := c.do("GET", path, nil, q, &resp); err != nil {
-			if err == ErrNotFound {
-				return []Workspace{}, ErrWorkspaceNotFound
-			}
-			return []Workspace{}, err
-		}
-		workspaces = append(workspaces, resp.Data...)
-	}
-	return workspaces, nil
+	return &WorkspaceList{
+		Items:      resp.Data,
+		Pagination: &resp.Meta.Pagination,
+	}, nil
 }
 
 // GetWorkspace gets a specific workspace
-func (c *Client) GetWorkspace(organization, workspace string) (Workspace, error) {
-	path := fmt.Sprintf("/api/v2/organizations/%s/workspaces/%s", organization, workspace)
+func (c *Client) GetWorkspace(ctx context.Context, organization, workspace string) (Workspace, error) {
+	path := fmt.Sprintf("/organizations/%s/workspaces/%s", organization, workspace)
 
 	type wrapper struct {
 		Data Workspace `json:"data"`
 	}
 
 	var resp wrapper
-	if err := c.do("GET", path, nil, nil, &resp); err != nil {
-		if err == ErrNotFound {
+	if err := c.do(ctx, "GET", path, nil, nil, &resp); err != nil {
+		if errors.Is(err, ErrNotFound) {
 			return Workspace{}, ErrWorkspaceNotFound
 		}
 		return Workspace{}, err
@@ -137,56 +182,63 @@ func (c *Client) GetWorkspace(organization, workspace string) (Workspace, error)
 	return resp.Data, nil
 }
 
-// ListStateVersions lists all state versions for a given workspace
-func (c *Client) ListStateVersions(organization, workspace string) ([]StateVersion, error) {
-	q := url.Values{}
-	q.Add("filter[organization][name]", organization)
-	q.Add("filter[workspace][name]", workspace)
-	svs := []StateVersion{}
+// StateVersionListOptions are the options for ListStateVersions. Organization
+// and Workspace are required filters; Terraform Enterprise has no concept of
+// listing state versions across workspaces.
+type StateVersionListOptions struct {
+	ListOptions
 
-	path := "/api/v2/state-versions"
+	Organization string `url:"filter[organization][name]"`
+	Workspace    string `url:"filter[workspace][name]"`
+}
+
+// StateVersionList is a single page of state versions, along with the
+// pagination metadata needed to fetch the next one.
+type StateVersionList struct {
+	Items      []StateVersion
+	Pagination *Pagination
+}
+
+// ListStateVersions lists a single page of state versions for a given
+// workspace. Use ListOptions.PageNumber to page through the full set.
+func (c *Client) ListStateVersions(ctx context.Context, opts StateVersionListOptions) (*StateVersionList, error) {
+	q, err := addQuery(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	path := "/state-versions"
 
 	type wrapper struct {
-		PaginatedResponse
+		paginatedResponse
 		Data []StateVersion `json:"data"`
 	}
 
 	var resp wrapper
-	if err := c.do("GET", path, nil, q, &resp); err != nil {
-		if err == ErrNotFound {
-			return []StateVersion{}, ErrStateVersionNotFound
-		}
-		return []StateVersion{}, err
-	}
-	svs = append(svs, resp.Data...)
-
-	for resp.Meta.Pagination.CurrentPage < resp.Meta.Pagination.TotalPages {
-		q = url.Values{}
-		q.Add("filter[organization][name]", organization)
-		q.Add("filter[workspace][name]", workspace)
-		q.Add("page[number]", strconv.Itoa(resp.Meta.Pagination.CurrentPage+1))
-		if err := c.do("GET", path, nil, q, &resp); err != nil {
-			if err == ErrNotFound {
-				return []StateVersion{}, ErrStateVersionNotFound
-			}
-			return []StateVersion{}, err
+	if err := c.do(ctx, "GET", path, nil, q, &resp); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, ErrStateVersionNotFound
 		}
-		svs = append(svs, resp.Data...)
+		return nil, err
 	}
-	return svs, nil
+
+	return &StateVersionList{
+		Items:      resp.Data,
+		Pagination: &resp.Meta.Pagination,
+	}, nil
 }
 
 // GetStateVersion gets a specific state version
-func (c *Client) GetStateVersion(organization, workspace, stateVersion string) (StateVersion, error) {
-	path := fmt.Sprintf("/api/v2/state-versions/%s", stateVersion)
+func (c *Client) GetStateVersion(ctx context.Context, organization, workspace, stateVersion string) (StateVersion, error) {
+	path := fmt.Sprintf("/state-versions/%s", stateVersion)
 
 	type wrapper struct {
 		Data StateVersion `json:"data"`
 	}
 
 	var resp wrapper
-	if err := c.do("GET", path, nil, nil, &resp); err != nil {
-		if err == ErrNotFound {
+	if err := c.do(ctx, "GET", path, nil, nil, &resp); err != nil {
+		if errors.Is(err, ErrNotFound) {
 			return StateVersion{}, ErrStateVersionNotFound
 		}
 		return StateVersion{}, err
@@ -196,13 +248,18 @@ func (c *Client) GetStateVersion(organization, workspace, stateVersion string) (
 }
 
 // DownloadState downloads the raw state file from Terraform Enterprise
-func (c *Client) DownloadState(organization, workspace, stateVersion string) ([]byte, error) {
-	sv, err := c.GetStateVersion(organization, workspace, stateVersion)
+func (c *Client) DownloadState(ctx context.Context, organization, workspace, stateVersion string) ([]byte, error) {
+	sv, err := c.GetStateVersion(ctx, organization, workspace, stateVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", sv.Attributes.HostedStateDownloadURL, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := http.Get(sv.Attributes.HostedStateDownloadURL)
+	resp, err := c.httpClient().Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -212,42 +269,158 @@ func (c *Client) DownloadState(organization, workspace, stateVersion string) ([]
 	return raw, err
 }
 
-func (c *Client) do(method string, path string, body io.Reader, query url.Values, recv interface{}) error {
-	parsed, err := url.Parse(c.BaseURL)
+// hostURL returns the base *url.URL requests are built against. Host may be
+// a bare hostname (scheme defaults to https) or a full URL, which is handy
+// for pointing tests at a plain-http httptest.Server.
+func (c *Client) hostURL() *url.URL {
+	return parseHostURL(c.Host)
+}
+
+// parseHostURL turns a host (a bare hostname, defaulting to https, or a
+// full URL such as a plain-http httptest.Server address) into a *url.URL
+// with Scheme and Host set, ready to have a Path appended.
+func parseHostURL(host string) *url.URL {
+	if u, err := url.Parse(host); err == nil && u.Scheme != "" && u.Host != "" {
+		return u
+	}
+	return &url.URL{Scheme: "https", Host: host}
+}
+
+// httpClient returns the configured transport, falling back to
+// http.DefaultClient for a zero-value Client.
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// userAgent returns the configured User-Agent, falling back to
+// defaultUserAgent for a zero-value Client.
+func (c *Client) userAgent() string {
+	if c.UserAgent != "" {
+		return c.UserAgent
+	}
+	return defaultUserAgent
+}
+
+// rateLimiter returns the client's token-bucket limiter, lazily
+// initializing one for a zero-value Client constructed without New.
+// limiterOnce makes that lazy init safe under the concurrent use this
+// limiter exists to coordinate.
+func (c *Client) rateLimiter() *rate.Limiter {
+	c.limiterOnce.Do(func() {
+		if c.limiter == nil {
+			c.limiter = rate.NewLimiter(rate.Limit(defaultRequestsPerSecond), defaultRequestsPerSecond)
+		}
+	})
+	return c.limiter
+}
+
+func (c *Client) do(ctx context.Context, method string, path string, body io.Reader, query url.Values, recv interface{}) error {
+	basePath, err := c.Discover(ctx, c.Host)
 	if err != nil {
 		return err
 	}
 
-	parsed.Path = path
+	parsed := c.hostURL()
+	parsed.Path = strings.TrimSuffix(basePath, "/") + path
 	if query == nil {
 		query = url.Values{}
 	}
 	parsed.RawQuery = query.Encode()
 
-	req, err := http.NewRequest(method, parsed.String(), body)
-	if err != nil {
-		return err
+	var bodyBytes []byte
+	if body != nil {
+		bodyBytes, err = ioutil.ReadAll(body)
+		if err != nil {
+			return err
+		}
 	}
 
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.AtlasToken))
-	req.Header.Add("Content-Type", "application/vnd.api+json")
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if err := c.rateLimiter().Wait(ctx); err != nil {
+			return err
+		}
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return err
+		var reqBody io.Reader
+		if bodyBytes != nil {
+			reqBody = bytes.NewReader(bodyBytes)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, parsed.String(), reqBody)
+		if err != nil {
+			return err
+		}
+
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.AtlasToken))
+		req.Header.Set("Content-Type", "application/vnd.api+json")
+		req.Header.Set("User-Agent", c.userAgent())
+		for key, values := range c.Headers {
+			for _, value := range values {
+				req.Header.Add(key, value)
+			}
+		}
+
+		resp, err := c.httpClient().Do(req)
+		if err != nil {
+			return err
+		}
+
+		if isRetryableStatus(resp.StatusCode) {
+			lastErr = decodeAPIError(resp.StatusCode, resp.Body)
+			resp.Body.Close()
+			if attempt == maxRetries {
+				break
+			}
+			wait := retryDelay(resp.Header.Get("Retry-After"), attempt)
+			select {
+			case <-time.After(wait):
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return decodeAPIError(resp.StatusCode, resp.Body)
+		}
+
+		if recv == nil || resp.StatusCode == http.StatusNoContent {
+			return nil
+		}
+
+		decoder := json.NewDecoder(resp.Body)
+		return decoder.Decode(&recv)
 	}
-	defer resp.Body.Close()
+	return lastErr
+}
 
-	switch {
-	case resp.StatusCode == 401:
-		return ErrUnauthorized
-	case resp.StatusCode == 404:
-		return ErrNotFound
-	case resp.StatusCode != 200:
-		return ErrBadStatus
+// isRetryableStatus reports whether a response should be retried: rate
+// limiting (429) or a server-side failure (5xx).
+func isRetryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// retryDelay honors a Retry-After header (either delay-seconds or an
+// HTTP-date, per RFC 7231) when present, and otherwise falls back to
+// exponential backoff with jitter.
+func retryDelay(retryAfter string, attempt int) time.Duration {
+	if retryAfter != "" {
+		if secs, err := strconv.Atoi(retryAfter); err == nil {
+			return time.Duration(secs) * time.Second
+		}
+		if t, err := http.ParseTime(retryAfter); err == nil {
+			if d := time.Until(t); d > 0 {
+				return d
+			}
+		}
 	}
 
-	decoder := json.NewDecoder(resp.Body)
-	err = decoder.Decode(&recv)
-	return err
+	backoff := time.Duration(float64(baseRetryDelay) * math.Pow(2, float64(attempt)))
+	jitter := time.Duration(rand.Int63n(int64(backoff)))
+	return backoff + jitter
 }
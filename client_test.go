@@ -0,0 +1,90 @@
+package tfe
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestRateLimiterConcurrentAccessIsSafe(t *testing.T) {
+	// A zero-value Client (no New) is the documented path that needs its
+	// own lazy-init guard, so exercise that directly rather than via New.
+	c := &Client{Host: "example.com"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if c.rateLimiter() == nil {
+				t.Error("rateLimiter returned nil")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestDoRetriesOnTooManyRequests(t *testing.T) {
+	var attempts int
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/terraform.json", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"tfe.v2.2":"/api/v2/"}`)
+	})
+	mux.HandleFunc("/api/v2/organizations", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		io.WriteString(w, `{"data":[],"meta":{"pagination":{"current-page":1,"next-page":0,"total-pages":1,"total-count":0}}}`)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := New("test-token", srv.URL)
+	if _, err := c.ListOrganizations(context.Background(), ListOptions{}); err != nil {
+		t.Fatalf("ListOrganizations: %v", err)
+	}
+	if attempts != 2 {
+		t.Fatalf("server saw %d attempts, want 2 (one 429 then a retry)", attempts)
+	}
+}
+
+func TestDoReturnsDecodedErrorWhenRetriesExhausted(t *testing.T) {
+	var attempts int
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/terraform.json", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"tfe.v2.2":"/api/v2/"}`)
+	})
+	mux.HandleFunc("/api/v2/organizations", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+		io.WriteString(w, `{"errors":[{"status":"429","title":"rate limited"}]}`)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := New("test-token", srv.URL)
+	_, err := c.ListOrganizations(context.Background(), ListOptions{})
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("ListOrganizations err = %v, want *APIError", err)
+	}
+	if apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("StatusCode = %d, want %d", apiErr.StatusCode, http.StatusTooManyRequests)
+	}
+	if attempts != maxRetries+1 {
+		t.Fatalf("server saw %d attempts, want %d (every retry exhausted)", attempts, maxRetries+1)
+	}
+}
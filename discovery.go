@@ -0,0 +1,151 @@
+package tfe
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ClientVersion is this SDK's version. It is compared against any
+// minimum_client_version a host's discovery document advertises for the
+// tfe service, so the client can fail fast with a clear error instead of
+// sending requests the server will reject.
+const ClientVersion = "0.1.0"
+
+// tfeServiceID is the service discovered in a host's
+// .well-known/terraform.json document that resolves the Terraform
+// Enterprise API's base path.
+const tfeServiceID = "tfe.v2.2"
+
+// wellKnownPath is the path discovery documents are served from, per the
+// Terraform remote-service discovery protocol.
+const wellKnownPath = "/.well-known/terraform.json"
+
+// discoveryDocument is a host's parsed .well-known/terraform.json: a flat
+// map from service ID to either a path string or an object describing the
+// service, such as a minimum client version.
+type discoveryDocument map[string]json.RawMessage
+
+// discoveryService is the long-form entry a discovery document may use
+// instead of a bare path string, to advertise a minimum client version.
+type discoveryService struct {
+	Path                 string `json:"path"`
+	MinimumClientVersion string `json:"minimum_client_version"`
+}
+
+// Discover fetches and caches host's service discovery document, returning
+// the resolved base path for the tfe.v2.2 service. Subsequent calls for the
+// same host reuse the cached document. Use SetDiscoveryOverride to pin a
+// host's base path in tests without making a network request.
+func (c *Client) Discover(ctx context.Context, host string) (string, error) {
+	c.discoveryMu.Lock()
+	defer c.discoveryMu.Unlock()
+
+	doc, ok := c.discoveryCache[host]
+	if !ok {
+		fetched, err := c.fetchDiscoveryDocument(ctx, host)
+		if err != nil {
+			return "", err
+		}
+		if c.discoveryCache == nil {
+			c.discoveryCache = map[string]discoveryDocument{}
+		}
+		c.discoveryCache[host] = fetched
+		doc = fetched
+	}
+
+	return resolveServicePath(doc, tfeServiceID)
+}
+
+// SetDiscoveryOverride pins host's resolved API base path without making a
+// network request. This is intended for tests and for custom Terraform
+// Enterprise installations whose discovery document isn't reachable from
+// the caller's network.
+func (c *Client) SetDiscoveryOverride(host, basePath string) {
+	c.discoveryMu.Lock()
+	defer c.discoveryMu.Unlock()
+
+	if c.discoveryCache == nil {
+		c.discoveryCache = map[string]discoveryDocument{}
+	}
+	encoded, _ := json.Marshal(basePath)
+	c.discoveryCache[host] = discoveryDocument{tfeServiceID: encoded}
+}
+
+func (c *Client) fetchDiscoveryDocument(ctx context.Context, host string) (discoveryDocument, error) {
+	u := parseHostURL(host)
+	u.Path = wellKnownPath
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.userAgent())
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("tfe: discovering services for %q: %w", host, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tfe: discovery request for %q returned status %d", host, resp.StatusCode)
+	}
+
+	var doc discoveryDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("tfe: decoding discovery document for %q: %w", host, err)
+	}
+	return doc, nil
+}
+
+// resolveServicePath looks up serviceID in doc, checking any advertised
+// minimum client version before returning the service's base path.
+func resolveServicePath(doc discoveryDocument, serviceID string) (string, error) {
+	raw, ok := doc[serviceID]
+	if !ok {
+		return "", fmt.Errorf("tfe: host does not advertise the %q service", serviceID)
+	}
+
+	var path string
+	if err := json.Unmarshal(raw, &path); err == nil {
+		return path, nil
+	}
+
+	var svc discoveryService
+	if err := json.Unmarshal(raw, &svc); err != nil {
+		return "", fmt.Errorf("tfe: malformed discovery entry for %q: %w", serviceID, err)
+	}
+
+	if svc.MinimumClientVersion != "" && compareVersions(ClientVersion, svc.MinimumClientVersion) < 0 {
+		return "", fmt.Errorf("tfe: server requires client version >= %s, this client is %s", svc.MinimumClientVersion, ClientVersion)
+	}
+
+	return svc.Path, nil
+}
+
+// compareVersions compares two dotted-integer version strings, returning a
+// negative number if a < b, zero if equal, and positive if a > b. It isn't
+// a full semver implementation (no pre-release/build metadata) but that's
+// all the discovery protocol needs here.
+func compareVersions(a, b string) int {
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var an, bn int
+		if i < len(as) {
+			an, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			bn, _ = strconv.Atoi(bs[i])
+		}
+		if an != bn {
+			return an - bn
+		}
+	}
+	return 0
+}
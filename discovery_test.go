@@ -0,0 +1,96 @@
+package tfe
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDiscoverResolvesBasePathFromHTTPTestServer(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/terraform.json", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"tfe.v2.2":"/api/v2/"}`)
+	})
+	mux.HandleFunc("/api/v2/organizations", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data":[],"meta":{"pagination":{"current-page":1,"next-page":0,"total-pages":1,"total-count":0}}}`)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	// srv.URL is a scheme-qualified address (http://127.0.0.1:PORT); this
+	// is the exact shape of Host that triggered the discovery bug where
+	// fetchDiscoveryDocument clobbered the parsed scheme/host.
+	c := New("test-token", srv.URL)
+
+	basePath, err := c.Discover(context.Background(), c.Host)
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	if basePath != "/api/v2/" {
+		t.Fatalf("basePath = %q, want %q", basePath, "/api/v2/")
+	}
+
+	list, err := c.ListOrganizations(context.Background(), ListOptions{})
+	if err != nil {
+		t.Fatalf("ListOrganizations: %v", err)
+	}
+	if len(list.Items) != 0 {
+		t.Fatalf("got %d organizations, want 0", len(list.Items))
+	}
+}
+
+func TestDiscoverCachesPerHost(t *testing.T) {
+	var hits int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/terraform.json", func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		io.WriteString(w, `{"tfe.v2.2":"/api/v2/"}`)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := New("test-token", srv.URL)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.Discover(ctx, c.Host); err != nil {
+			t.Fatalf("Discover: %v", err)
+		}
+	}
+
+	if hits != 1 {
+		t.Fatalf("discovery document fetched %d times, want 1 (should be cached)", hits)
+	}
+}
+
+func TestDiscoverFailsFastOnUnmetMinimumClientVersion(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/terraform.json", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"tfe.v2.2":{"path":"/api/v2/","minimum_client_version":"99.0.0"}}`)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := New("test-token", srv.URL)
+	if _, err := c.Discover(context.Background(), c.Host); err == nil {
+		t.Fatal("Discover: expected an error for an unmet minimum client version, got nil")
+	}
+}
+
+func TestSetDiscoveryOverrideSkipsTheNetworkRequest(t *testing.T) {
+	c := New("test-token", "tfe.example.com")
+	c.SetDiscoveryOverride(c.Host, "/custom/api/path/")
+
+	basePath, err := c.Discover(context.Background(), c.Host)
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	if basePath != "/custom/api/path/" {
+		t.Fatalf("basePath = %q, want %q", basePath, "/custom/api/path/")
+	}
+}
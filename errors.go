@@ -0,0 +1,92 @@
+package tfe
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ErrorObjectSource points at the part of the request that a JSON:API
+// error object pertains to.
+type ErrorObjectSource struct {
+	Pointer string `json:"pointer"`
+}
+
+// ErrorObject is a single JSON:API error, as documented at
+// https://jsonapi.org/format/#error-objects.
+type ErrorObject struct {
+	Status string             `json:"status"`
+	Title  string             `json:"title"`
+	Detail string             `json:"detail"`
+	Source *ErrorObjectSource `json:"source,omitempty"`
+}
+
+// APIError wraps a non-2xx Terraform Enterprise response, preserving the
+// status code and any JSON:API error objects the server returned so callers
+// can surface field-level validation detail (Source.Pointer, Detail)
+// instead of a generic message.
+type APIError struct {
+	StatusCode int
+	Errors     []ErrorObject
+}
+
+func (e *APIError) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("tfe: request failed with status %d", e.StatusCode)
+	}
+
+	detail := e.Errors[0].Detail
+	if detail == "" {
+		detail = e.Errors[0].Title
+	}
+	if src := e.Errors[0].Source; src != nil && src.Pointer != "" {
+		return fmt.Sprintf("tfe: request failed with status %d: %s (%s)", e.StatusCode, detail, src.Pointer)
+	}
+	return fmt.Sprintf("tfe: request failed with status %d: %s", e.StatusCode, detail)
+}
+
+// Unwrap lets errors.Is match an APIError against the package's sentinel
+// errors (ErrUnauthorized, ErrNotFound, ErrBadStatus) based on status code,
+// so callers written against those sentinels keep working unchanged.
+func (e *APIError) Unwrap() error {
+	switch e.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrUnauthorized
+	case http.StatusNotFound:
+		return ErrNotFound
+	default:
+		return ErrBadStatus
+	}
+}
+
+// IsNotFound reports whether the request failed because the resource
+// doesn't exist.
+func (e *APIError) IsNotFound() bool {
+	return e.StatusCode == http.StatusNotFound
+}
+
+// IsConflict reports whether the request failed due to a conflicting or
+// invalid resource state, such as a workspace that's already locked.
+func (e *APIError) IsConflict() bool {
+	return e.StatusCode == http.StatusConflict
+}
+
+// IsRateLimited reports whether the request failed because the client
+// exceeded Terraform Enterprise's rate limit.
+func (e *APIError) IsRateLimited() bool {
+	return e.StatusCode == http.StatusTooManyRequests
+}
+
+// decodeAPIError builds an APIError from a non-2xx response body, which
+// Terraform Enterprise encodes as a JSON:API error document:
+// {"errors":[{"status":"...","title":"...","detail":"...","source":{"pointer":"..."}}]}.
+// The body is decoded on a best-effort basis; a malformed or empty body
+// still yields an APIError carrying the status code.
+func decodeAPIError(statusCode int, body io.Reader) *APIError {
+	var doc struct {
+		Errors []ErrorObject `json:"errors"`
+	}
+	_ = json.NewDecoder(body).Decode(&doc)
+	return &APIError{StatusCode: statusCode, Errors: doc.Errors}
+}
@@ -0,0 +1,76 @@
+package tfe
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDecodeAPIErrorParsesJSONAPIErrorDocument(t *testing.T) {
+	body := strings.NewReader(`{"errors":[{"status":"422","title":"invalid attribute","detail":"name is required","source":{"pointer":"/data/attributes/name"}}]}`)
+
+	apiErr := decodeAPIError(http.StatusUnprocessableEntity, body)
+
+	if apiErr.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("StatusCode = %d, want %d", apiErr.StatusCode, http.StatusUnprocessableEntity)
+	}
+	if len(apiErr.Errors) != 1 {
+		t.Fatalf("Errors = %v, want 1 entry", apiErr.Errors)
+	}
+	if apiErr.Errors[0].Detail != "name is required" {
+		t.Errorf("Errors[0].Detail = %q, want %q", apiErr.Errors[0].Detail, "name is required")
+	}
+
+	wantMsg := "tfe: request failed with status 422: name is required (/data/attributes/name)"
+	if got := apiErr.Error(); got != wantMsg {
+		t.Errorf("Error() = %q, want %q", got, wantMsg)
+	}
+}
+
+func TestDecodeAPIErrorHandlesMalformedBody(t *testing.T) {
+	apiErr := decodeAPIError(http.StatusInternalServerError, strings.NewReader("not json"))
+
+	if apiErr.StatusCode != http.StatusInternalServerError {
+		t.Errorf("StatusCode = %d, want %d", apiErr.StatusCode, http.StatusInternalServerError)
+	}
+	if len(apiErr.Errors) != 0 {
+		t.Errorf("Errors = %v, want none", apiErr.Errors)
+	}
+
+	wantMsg := "tfe: request failed with status 500"
+	if got := apiErr.Error(); got != wantMsg {
+		t.Errorf("Error() = %q, want %q", got, wantMsg)
+	}
+}
+
+func TestAPIErrorUnwrapMatchesSentinelsByStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		want   error
+	}{
+		{http.StatusUnauthorized, ErrUnauthorized},
+		{http.StatusForbidden, ErrUnauthorized},
+		{http.StatusNotFound, ErrNotFound},
+		{http.StatusInternalServerError, ErrBadStatus},
+	}
+
+	for _, tt := range tests {
+		apiErr := &APIError{StatusCode: tt.status}
+		if !errors.Is(apiErr, tt.want) {
+			t.Errorf("status %d: errors.Is(apiErr, %v) = false, want true", tt.status, tt.want)
+		}
+	}
+}
+
+func TestAPIErrorHelpers(t *testing.T) {
+	if !(&APIError{StatusCode: http.StatusNotFound}).IsNotFound() {
+		t.Error("IsNotFound() = false for 404")
+	}
+	if !(&APIError{StatusCode: http.StatusConflict}).IsConflict() {
+		t.Error("IsConflict() = false for 409")
+	}
+	if !(&APIError{StatusCode: http.StatusTooManyRequests}).IsRateLimited() {
+		t.Error("IsRateLimited() = false for 429")
+	}
+}
@@ -0,0 +1,133 @@
+package tfe
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/google/go-querystring/query"
+)
+
+// ListOptions is used to specify pagination options when making a List
+// request. Pagination allows for large result sets to be fetched a page at
+// a time instead of walking the whole collection eagerly.
+type ListOptions struct {
+	// PageNumber is the page to fetch. The first page is 1, which is also
+	// the default applied when PageNumber is left at its zero value.
+	PageNumber int `url:"page[number],omitempty"`
+
+	// PageSize is the number of items to return per page.
+	PageSize int `url:"page[size],omitempty"`
+}
+
+// Pagination holds the metadata Terraform Enterprise returns alongside a
+// page of list results.
+type Pagination struct {
+	CurrentPage int `json:"current-page"`
+	PrevPage    int `json:"prev-page"`
+	NextPage    int `json:"next-page"`
+	TotalPages  int `json:"total-pages"`
+	TotalCount  int `json:"total-count"`
+}
+
+// paginatedResponse is embedded in the JSON:API response wrapper for any
+// List endpoint to pull out the pagination metadata.
+type paginatedResponse struct {
+	Meta struct {
+		Pagination Pagination `json:"pagination"`
+	} `json:"meta"`
+}
+
+// addQuery encodes opts (a ListOptions, or a struct embedding one) into
+// query string parameters using its `url` struct tags, following the
+// google/go-querystring convention.
+func addQuery(opts interface{}) (url.Values, error) {
+	if opts == nil {
+		return nil, nil
+	}
+	return query.Values(opts)
+}
+
+// paginator drives page-by-page iteration for any List endpoint. Resources
+// wrap it in a typed iterator (e.g. WorkspaceIterator) so callers get back
+// concrete items from a field rather than interface{}.
+type paginator struct {
+	ctx     context.Context
+	fetch   func(ctx context.Context, pageNumber int) (*Pagination, error)
+	current int
+	total   int
+	started bool
+	err     error
+}
+
+func newPaginator(ctx context.Context, fetch func(context.Context, int) (*Pagination, error)) *paginator {
+	return &paginator{ctx: ctx, fetch: fetch}
+}
+
+// Next fetches the next page, returning false once there are no more pages
+// or a fetch fails. Callers should check Err after Next returns false.
+func (p *paginator) Next() bool {
+	if p.err != nil {
+		return false
+	}
+	if p.started && p.current >= p.total {
+		return false
+	}
+
+	page := 1
+	if p.started {
+		page = p.current + 1
+	}
+
+	pagination, err := p.fetch(p.ctx, page)
+	if err != nil {
+		p.err = err
+		return false
+	}
+
+	p.started = true
+	p.current = pagination.CurrentPage
+	p.total = pagination.TotalPages
+	return true
+}
+
+// Err returns the error, if any, that stopped iteration early.
+func (p *paginator) Err() error {
+	return p.err
+}
+
+// WorkspaceIterator streams the workspaces in an organization one page at a
+// time, fetching lazily as Next is called so callers can stop early.
+type WorkspaceIterator struct {
+	*paginator
+
+	// Items holds the workspaces fetched by the most recent call to Next.
+	Items []Workspace
+}
+
+// Workspaces returns an accessor for workspace operations that stream
+// results, such as Iter.
+func (c *Client) Workspaces() *WorkspacesService {
+	return &WorkspacesService{client: c}
+}
+
+// WorkspacesService groups streaming workspace operations on top of the
+// Client's flat ListWorkspaces/GetWorkspace methods.
+type WorkspacesService struct {
+	client *Client
+}
+
+// Iter returns an iterator over every workspace in organization.
+func (s *WorkspacesService) Iter(ctx context.Context, organization string, opts ListOptions) *WorkspaceIterator {
+	iter := &WorkspaceIterator{}
+	iter.paginator = newPaginator(ctx, func(ctx context.Context, page int) (*Pagination, error) {
+		pageOpts := opts
+		pageOpts.PageNumber = page
+		list, err := s.client.ListWorkspaces(ctx, organization, pageOpts)
+		if err != nil {
+			return nil, err
+		}
+		iter.Items = list.Items
+		return list.Pagination, nil
+	})
+	return iter
+}
@@ -0,0 +1,85 @@
+package tfe
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestPaginatorFirstPageDefaultsToOne(t *testing.T) {
+	var sawPage int
+	p := newPaginator(context.Background(), func(ctx context.Context, page int) (*Pagination, error) {
+		sawPage = page
+		return &Pagination{CurrentPage: 1, TotalPages: 1}, nil
+	})
+
+	if !p.Next() {
+		t.Fatalf("Next() = false, want true on first page")
+	}
+	if sawPage != 1 {
+		t.Errorf("fetch called with page %d, want 1", sawPage)
+	}
+}
+
+func TestPaginatorStopsAfterLastPage(t *testing.T) {
+	pages := []Pagination{
+		{CurrentPage: 1, TotalPages: 2},
+		{CurrentPage: 2, TotalPages: 2},
+	}
+	var calls int
+	p := newPaginator(context.Background(), func(ctx context.Context, page int) (*Pagination, error) {
+		got := pages[calls]
+		calls++
+		return &got, nil
+	})
+
+	for p.Next() {
+	}
+
+	if calls != 2 {
+		t.Fatalf("fetch called %d times, want 2", calls)
+	}
+	if err := p.Err(); err != nil {
+		t.Fatalf("Err() = %v, want nil", err)
+	}
+}
+
+func TestPaginatorStopsOnFetchError(t *testing.T) {
+	wantErr := errors.New("boom")
+	p := newPaginator(context.Background(), func(ctx context.Context, page int) (*Pagination, error) {
+		return nil, wantErr
+	})
+
+	if p.Next() {
+		t.Fatalf("Next() = true, want false on fetch error")
+	}
+	if !errors.Is(p.Err(), wantErr) {
+		t.Fatalf("Err() = %v, want %v", p.Err(), wantErr)
+	}
+	if p.Next() {
+		t.Fatalf("Next() = true after an error, want it to stay stopped")
+	}
+}
+
+func TestPaginatorRequestsSubsequentPagesInOrder(t *testing.T) {
+	var gotPages []int
+	total := 3
+	p := newPaginator(context.Background(), func(ctx context.Context, page int) (*Pagination, error) {
+		gotPages = append(gotPages, page)
+		return &Pagination{CurrentPage: page, TotalPages: total}, nil
+	})
+
+	for p.Next() {
+	}
+
+	want := []int{1, 2, 3}
+	if len(gotPages) != len(want) {
+		t.Fatalf("fetched pages %v, want %v", gotPages, want)
+	}
+	for i, page := range want {
+		if gotPages[i] != page {
+			t.Errorf("fetched pages %v, want %v", gotPages, want)
+			break
+		}
+	}
+}
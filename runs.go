@@ -0,0 +1,427 @@
+package tfe
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// RunStatus is the lifecycle state of a run, as reported by Terraform
+// Enterprise.
+type RunStatus string
+
+// Run statuses Terraform Enterprise reports. This isn't the full set TFE
+// can report (e.g. "confirmed", "policy_checked"), just the ones this
+// package's callers need to branch on.
+const (
+	RunStatusPending   RunStatus = "pending"
+	RunStatusPlanning  RunStatus = "planning"
+	RunStatusPlanned   RunStatus = "planned"
+	RunStatusApplying  RunStatus = "applying"
+	RunStatusApplied   RunStatus = "applied"
+	RunStatusErrored   RunStatus = "errored"
+	RunStatusCanceled  RunStatus = "canceled"
+	RunStatusDiscarded RunStatus = "discarded"
+)
+
+// IsTerminal reports whether s is a status a run won't transition out of.
+func (s RunStatus) IsTerminal() bool {
+	switch s {
+	case RunStatusApplied, RunStatusErrored, RunStatusCanceled, RunStatusDiscarded:
+		return true
+	}
+	return false
+}
+
+const jsonAPITypeRuns = "runs"
+
+// Run represents a single plan/apply execution against a workspace.
+type Run struct {
+	ID         string        `json:"id"`
+	Attributes RunAttributes `json:"attributes"`
+}
+
+// RunAttributes are the attributes Terraform Enterprise reports for a run.
+type RunAttributes struct {
+	Status    string `json:"status"`
+	Message   string `json:"message"`
+	IsDestroy bool   `json:"is-destroy"`
+}
+
+// RunList is a single page of runs, along with the pagination metadata
+// needed to fetch the next one.
+type RunList struct {
+	Items      []Run
+	Pagination *Pagination
+}
+
+// RunCreateOptions are the attributes accepted when queuing a new run.
+type RunCreateOptions struct {
+	Message   string `json:"message,omitempty"`
+	IsDestroy bool   `json:"is-destroy,omitempty"`
+}
+
+// CreateRun queues a new run (plan, and, once confirmed, apply) against
+// workspaceID.
+func (c *Client) CreateRun(ctx context.Context, workspaceID string, opts RunCreateOptions) (Run, error) {
+	body, err := newJSONAPIBodyWithRelationships(jsonAPITypeRuns, opts, map[string]jsonAPIRelationship{
+		"workspace": {Data: jsonAPIResourceID{Type: jsonAPITypeWorkspaces, ID: workspaceID}},
+	})
+	if err != nil {
+		return Run{}, err
+	}
+
+	type wrapper struct {
+		Data Run `json:"data"`
+	}
+
+	var resp wrapper
+	if err := c.do(ctx, "POST", "/runs", body, nil, &resp); err != nil {
+		return Run{}, err
+	}
+	return resp.Data, nil
+}
+
+// GetRun fetches a single run by ID.
+func (c *Client) GetRun(ctx context.Context, runID string) (Run, error) {
+	path := fmt.Sprintf("/runs/%s", runID)
+
+	type wrapper struct {
+		Data Run `json:"data"`
+	}
+
+	var resp wrapper
+	if err := c.do(ctx, "GET", path, nil, nil, &resp); err != nil {
+		return Run{}, err
+	}
+	return resp.Data, nil
+}
+
+// ListRuns lists a single page of runs queued against a workspace.
+func (c *Client) ListRuns(ctx context.Context, workspaceID string, opts ListOptions) (*RunList, error) {
+	q, err := addQuery(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	path := fmt.Sprintf("/workspaces/%s/runs", workspaceID)
+
+	type wrapper struct {
+		paginatedResponse
+		Data []Run `json:"data"`
+	}
+
+	var resp wrapper
+	if err := c.do(ctx, "GET", path, nil, q, &resp); err != nil {
+		return nil, err
+	}
+
+	return &RunList{
+		Items:      resp.Data,
+		Pagination: &resp.Meta.Pagination,
+	}, nil
+}
+
+// ApplyRun confirms a planned run so its apply can proceed. comment is
+// optional and may be empty.
+func (c *Client) ApplyRun(ctx context.Context, runID, comment string) error {
+	return c.runAction(ctx, runID, "apply", comment)
+}
+
+// DiscardRun discards a run that's awaiting confirmation, skipping its
+// apply. comment is optional and may be empty.
+func (c *Client) DiscardRun(ctx context.Context, runID, comment string) error {
+	return c.runAction(ctx, runID, "discard", comment)
+}
+
+// CancelRun cancels a run that's currently planning or applying. comment is
+// optional and may be empty.
+func (c *Client) CancelRun(ctx context.Context, runID, comment string) error {
+	return c.runAction(ctx, runID, "cancel", comment)
+}
+
+func (c *Client) runAction(ctx context.Context, runID, action, comment string) error {
+	type actionAttributes struct {
+		Comment string `json:"comment,omitempty"`
+	}
+
+	body, err := newJSONAPIBody(jsonAPITypeRuns, actionAttributes{Comment: comment})
+	if err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("/runs/%s/actions/%s", runID, action)
+	return c.do(ctx, "POST", path, body, nil, nil)
+}
+
+// Plan represents the plan phase of a run.
+type Plan struct {
+	ID         string         `json:"id"`
+	Attributes PlanAttributes `json:"attributes"`
+}
+
+// PlanAttributes are the attributes Terraform Enterprise reports for a
+// plan.
+type PlanAttributes struct {
+	Status     string `json:"status"`
+	LogReadURL string `json:"log-read-url"`
+}
+
+// Apply represents the apply phase of a run.
+type Apply struct {
+	ID         string          `json:"id"`
+	Attributes ApplyAttributes `json:"attributes"`
+}
+
+// ApplyAttributes are the attributes Terraform Enterprise reports for an
+// apply.
+type ApplyAttributes struct {
+	Status     string `json:"status"`
+	LogReadURL string `json:"log-read-url"`
+}
+
+// PlansService groups operations on a run's plan phase.
+type PlansService struct {
+	client *Client
+}
+
+// Plans returns the accessor for plan operations.
+func (c *Client) Plans() *PlansService {
+	return &PlansService{client: c}
+}
+
+// Get fetches a single plan by ID.
+func (s *PlansService) Get(ctx context.Context, planID string) (Plan, error) {
+	path := fmt.Sprintf("/plans/%s", planID)
+
+	type wrapper struct {
+		Data Plan `json:"data"`
+	}
+
+	var resp wrapper
+	if err := s.client.do(ctx, "GET", path, nil, nil, &resp); err != nil {
+		return Plan{}, err
+	}
+	return resp.Data, nil
+}
+
+// Logs streams a plan's log output. Bytes are delivered as they're
+// produced; the stream ends once the plan's own status, read as a
+// RunStatus, is terminal (see RunStatus.IsTerminal). The status of the run
+// the plan belongs to is not consulted. Closing the returned ReadCloser
+// stops polling.
+func (s *PlansService) Logs(ctx context.Context, planID string) (io.ReadCloser, error) {
+	plan, err := s.Get(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.client.streamLogs(ctx, plan.Attributes.LogReadURL, func(ctx context.Context) (RunStatus, error) {
+		p, err := s.Get(ctx, planID)
+		if err != nil {
+			return "", err
+		}
+		return RunStatus(p.Attributes.Status), nil
+	})
+}
+
+// AppliesService groups operations on a run's apply phase.
+type AppliesService struct {
+	client *Client
+}
+
+// Applies returns the accessor for apply operations.
+func (c *Client) Applies() *AppliesService {
+	return &AppliesService{client: c}
+}
+
+// Get fetches a single apply by ID.
+func (s *AppliesService) Get(ctx context.Context, applyID string) (Apply, error) {
+	path := fmt.Sprintf("/applies/%s", applyID)
+
+	type wrapper struct {
+		Data Apply `json:"data"`
+	}
+
+	var resp wrapper
+	if err := s.client.do(ctx, "GET", path, nil, nil, &resp); err != nil {
+		return Apply{}, err
+	}
+	return resp.Data, nil
+}
+
+// Logs streams an apply's log output. Bytes are delivered as they're
+// produced; the stream ends once the apply's own status, read as a
+// RunStatus, is terminal (see RunStatus.IsTerminal). The status of the run
+// the apply belongs to is not consulted. Closing the returned ReadCloser
+// stops polling.
+func (s *AppliesService) Logs(ctx context.Context, applyID string) (io.ReadCloser, error) {
+	apply, err := s.Get(ctx, applyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.client.streamLogs(ctx, apply.Attributes.LogReadURL, func(ctx context.Context) (RunStatus, error) {
+		a, err := s.Get(ctx, applyID)
+		if err != nil {
+			return "", err
+		}
+		return RunStatus(a.Attributes.Status), nil
+	})
+}
+
+// logPollInterval is how often streamLogs checks for new log output.
+const logPollInterval = 1 * time.Second
+
+// streamLogs polls logURL for appended bytes, writing them to the returned
+// ReadCloser as they appear, until statusFunc reports a terminal run
+// status and no further bytes are available.
+func (c *Client) streamLogs(ctx context.Context, logURL string, statusFunc func(context.Context) (RunStatus, error)) (io.ReadCloser, error) {
+	pr, pw := io.Pipe()
+	ctx, cancel := context.WithCancel(ctx)
+
+	go func() {
+		defer cancel()
+
+		var offset int64
+		ticker := time.NewTicker(logPollInterval)
+		defer ticker.Stop()
+
+		for {
+			chunk, err := c.fetchLogChunk(ctx, logURL, offset)
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+
+			if len(chunk) > 0 {
+				if _, err := pw.Write(chunk); err != nil {
+					return
+				}
+				offset += int64(len(chunk))
+			}
+
+			if len(chunk) == 0 {
+				status, err := statusFunc(ctx)
+				if err != nil {
+					pw.CloseWithError(err)
+					return
+				}
+				if status.IsTerminal() {
+					pw.Close()
+					return
+				}
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				pw.CloseWithError(ctx.Err())
+				return
+			}
+		}
+	}()
+
+	return &logReadCloser{PipeReader: pr, cancel: cancel}, nil
+}
+
+// logReadCloser cancels streamLogs's derived context when closed, so a
+// caller that stops reading before the run reaches a terminal status (an
+// idle log that never produces another chunk, say) still stops the
+// background poller instead of leaking it.
+type logReadCloser struct {
+	*io.PipeReader
+	cancel context.CancelFunc
+}
+
+func (l *logReadCloser) Close() error {
+	l.cancel()
+	return l.PipeReader.Close()
+}
+
+// fetchLogChunk fetches the log bytes available at logURL past offset,
+// using a Range request so repeated polls only transfer new output.
+func (c *Client) fetchLogChunk(ctx context.Context, logURL string, offset int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", logURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return nil, fmt.Errorf("tfe: fetching log output returned status %d", resp.StatusCode)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+// defaultWaitPollInterval is how often Wait re-checks run status.
+const defaultWaitPollInterval = 2 * time.Second
+
+// WaitOptions configures Client.Wait.
+type WaitOptions struct {
+	// PollInterval is how often to re-check run status. Defaults to
+	// defaultWaitPollInterval.
+	PollInterval time.Duration
+
+	// StatusCh, if non-nil, receives the run's status whenever a poll
+	// finds it different from the previous poll, starting with its status
+	// at the time Wait was called. A status the run enters and leaves
+	// between two polls is not sent. Wait closes StatusCh before returning.
+	StatusCh chan<- RunStatus
+}
+
+// Wait blocks until run reaches a terminal status (applied, errored,
+// canceled, or discarded), returning the final Run. If opts.StatusCh is
+// set, each status change seen between polls is reported on it before
+// Wait returns.
+func (c *Client) Wait(ctx context.Context, runID string, opts WaitOptions) (Run, error) {
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = defaultWaitPollInterval
+	}
+	if opts.StatusCh != nil {
+		defer close(opts.StatusCh)
+	}
+
+	var last RunStatus
+	for {
+		run, err := c.GetRun(ctx, runID)
+		if err != nil {
+			return Run{}, err
+		}
+
+		status := RunStatus(run.Attributes.Status)
+		if status != last {
+			last = status
+			if opts.StatusCh != nil {
+				select {
+				case opts.StatusCh <- status:
+				case <-ctx.Done():
+					return Run{}, ctx.Err()
+				}
+			}
+		}
+
+		if status.IsTerminal() {
+			return run, nil
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return Run{}, ctx.Err()
+		}
+	}
+}
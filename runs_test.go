@@ -0,0 +1,108 @@
+package tfe
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStreamLogsDeliversChunksUntilTerminalStatus(t *testing.T) {
+	chunks := []string{"line one\n", "line two\n"}
+
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		if n <= int32(len(chunks)) {
+			io.WriteString(w, chunks[n-1])
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := New("test-token", srv.URL)
+
+	var statusCalls int32
+	rc, err := c.streamLogs(context.Background(), srv.URL, func(ctx context.Context) (RunStatus, error) {
+		if atomic.AddInt32(&statusCalls, 1) >= 2 {
+			return RunStatusApplied, nil
+		}
+		return RunStatusApplying, nil
+	})
+	if err != nil {
+		t.Fatalf("streamLogs: %v", err)
+	}
+	defer rc.Close()
+
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("reading stream: %v", err)
+	}
+
+	want := chunks[0] + chunks[1]
+	if string(got) != want {
+		t.Fatalf("stream content = %q, want %q", got, want)
+	}
+}
+
+func TestStreamLogsStopsPollingWhenReaderIsClosed(t *testing.T) {
+	var requests int32
+	firstRequest := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			close(firstRequest)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := New("test-token", srv.URL)
+	rc, err := c.streamLogs(context.Background(), srv.URL, func(ctx context.Context) (RunStatus, error) {
+		return RunStatusPlanning, nil
+	})
+	if err != nil {
+		t.Fatalf("streamLogs: %v", err)
+	}
+
+	select {
+	case <-firstRequest:
+	case <-time.After(2 * time.Second):
+		t.Fatal("poller never made its first fetch")
+	}
+
+	if err := rc.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	time.Sleep(1200 * time.Millisecond)
+	after := atomic.LoadInt32(&requests)
+
+	time.Sleep(1200 * time.Millisecond)
+	if got := atomic.LoadInt32(&requests); got != after {
+		t.Fatalf("poller kept fetching after Close: %d requests, then %d", after, got)
+	}
+}
+
+func TestStreamLogsPropagatesFetchErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	c := New("test-token", srv.URL)
+	rc, err := c.streamLogs(context.Background(), srv.URL, func(ctx context.Context) (RunStatus, error) {
+		return RunStatusPlanning, nil
+	})
+	if err != nil {
+		t.Fatalf("streamLogs: %v", err)
+	}
+	defer rc.Close()
+
+	if _, err := io.ReadAll(rc); err == nil {
+		t.Fatal("ReadAll err = nil, want an error from the failing log fetch")
+	}
+}
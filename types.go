@@ -0,0 +1,49 @@
+package tfe
+
+// Organization is a Terraform Enterprise organization.
+type Organization struct {
+	ID         string                 `json:"id"`
+	Attributes OrganizationAttributes `json:"attributes"`
+}
+
+// OrganizationAttributes are the attributes Terraform Enterprise reports
+// for an organization.
+type OrganizationAttributes struct {
+	Name string `json:"name"`
+}
+
+// Workspace is a Terraform Enterprise workspace.
+type Workspace struct {
+	ID         string              `json:"id"`
+	Attributes WorkspaceAttributes `json:"attributes"`
+}
+
+// WorkspaceAttributes are the attributes Terraform Enterprise reports for
+// a workspace.
+type WorkspaceAttributes struct {
+	Name             string `json:"name"`
+	TerraformVersion string `json:"terraform-version"`
+	WorkingDirectory string `json:"working-directory"`
+	AutoApply        bool   `json:"auto-apply"`
+	Locked           bool   `json:"locked"`
+}
+
+// StateVersion is a single uploaded state file for a workspace.
+type StateVersion struct {
+	ID         string                 `json:"id"`
+	Attributes StateVersionAttributes `json:"attributes"`
+}
+
+// StateVersionAttributes are the attributes Terraform Enterprise reports
+// for a state version.
+type StateVersionAttributes struct {
+	Serial int64  `json:"serial"`
+	MD5    string `json:"md5"`
+
+	// Lineage is the state file's lineage identifier.
+	Lineage string `json:"lineage"`
+
+	// HostedStateDownloadURL fetches the raw state file this version
+	// points at. See Client.DownloadState.
+	HostedStateDownloadURL string `json:"hosted-state-download-url"`
+}
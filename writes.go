@@ -0,0 +1,227 @@
+package tfe
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// JSON:API resource type identifiers used when encoding request bodies.
+const (
+	jsonAPITypeWorkspaces    = "workspaces"
+	jsonAPITypeStateVersions = "state-versions"
+)
+
+// jsonAPIRequest is the envelope Terraform Enterprise expects for create and
+// update requests: {"data":{"type":"...","attributes":{...}}}.
+type jsonAPIRequest struct {
+	Data jsonAPIRequestData `json:"data"`
+}
+
+type jsonAPIRequestData struct {
+	Type          string                         `json:"type"`
+	Attributes    interface{}                    `json:"attributes,omitempty"`
+	Relationships map[string]jsonAPIRelationship `json:"relationships,omitempty"`
+}
+
+// jsonAPIResourceID identifies a related resource by type and ID.
+type jsonAPIResourceID struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// jsonAPIRelationship is a to-one relationship in a JSON:API request body.
+type jsonAPIRelationship struct {
+	Data jsonAPIResourceID `json:"data"`
+}
+
+// newJSONAPIBody encodes attributes as a JSON:API request body for typ.
+func newJSONAPIBody(typ string, attributes interface{}) (io.Reader, error) {
+	return newJSONAPIBodyWithRelationships(typ, attributes, nil)
+}
+
+// newJSONAPIBodyWithRelationships encodes attributes and relationships
+// (keyed by relationship name, e.g. "workspace") as a JSON:API request body
+// for typ.
+func newJSONAPIBodyWithRelationships(typ string, attributes interface{}, relationships map[string]jsonAPIRelationship) (io.Reader, error) {
+	b, err := json.Marshal(jsonAPIRequest{Data: jsonAPIRequestData{
+		Type:          typ,
+		Attributes:    attributes,
+		Relationships: relationships,
+	}})
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(b), nil
+}
+
+// WorkspaceCreateOptions are the attributes accepted when creating a
+// workspace.
+type WorkspaceCreateOptions struct {
+	Name             string `json:"name"`
+	TerraformVersion string `json:"terraform-version,omitempty"`
+	WorkingDirectory string `json:"working-directory,omitempty"`
+	AutoApply        bool   `json:"auto-apply,omitempty"`
+}
+
+// WorkspaceUpdateOptions are the attributes accepted when updating a
+// workspace. Fields left at their zero value are omitted from the request
+// and leave the existing value on the workspace unchanged.
+type WorkspaceUpdateOptions struct {
+	Name             string `json:"name,omitempty"`
+	TerraformVersion string `json:"terraform-version,omitempty"`
+	WorkingDirectory string `json:"working-directory,omitempty"`
+	AutoApply        *bool  `json:"auto-apply,omitempty"`
+}
+
+// CreateWorkspace creates a new workspace in organization.
+func (c *Client) CreateWorkspace(ctx context.Context, organization string, opts WorkspaceCreateOptions) (Workspace, error) {
+	body, err := newJSONAPIBody(jsonAPITypeWorkspaces, opts)
+	if err != nil {
+		return Workspace{}, err
+	}
+
+	path := fmt.Sprintf("/organizations/%s/workspaces", organization)
+
+	type wrapper struct {
+		Data Workspace `json:"data"`
+	}
+
+	var resp wrapper
+	if err := c.do(ctx, "POST", path, body, nil, &resp); err != nil {
+		return Workspace{}, err
+	}
+	return resp.Data, nil
+}
+
+// UpdateWorkspace updates an existing workspace.
+func (c *Client) UpdateWorkspace(ctx context.Context, organization, workspace string, opts WorkspaceUpdateOptions) (Workspace, error) {
+	body, err := newJSONAPIBody(jsonAPITypeWorkspaces, opts)
+	if err != nil {
+		return Workspace{}, err
+	}
+
+	path := fmt.Sprintf("/organizations/%s/workspaces/%s", organization, workspace)
+
+	type wrapper struct {
+		Data Workspace `json:"data"`
+	}
+
+	var resp wrapper
+	if err := c.do(ctx, "PATCH", path, body, nil, &resp); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return Workspace{}, ErrWorkspaceNotFound
+		}
+		return Workspace{}, err
+	}
+	return resp.Data, nil
+}
+
+// DeleteWorkspace deletes a workspace.
+func (c *Client) DeleteWorkspace(ctx context.Context, organization, workspace string) error {
+	path := fmt.Sprintf("/organizations/%s/workspaces/%s", organization, workspace)
+	if err := c.do(ctx, "DELETE", path, nil, nil, nil); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return ErrWorkspaceNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// LockWorkspace locks a workspace, preventing new runs from starting.
+func (c *Client) LockWorkspace(ctx context.Context, workspaceID string) (Workspace, error) {
+	path := fmt.Sprintf("/workspaces/%s/actions/lock", workspaceID)
+
+	type wrapper struct {
+		Data Workspace `json:"data"`
+	}
+
+	var resp wrapper
+	if err := c.do(ctx, "POST", path, nil, nil, &resp); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return Workspace{}, ErrWorkspaceNotFound
+		}
+		return Workspace{}, err
+	}
+	return resp.Data, nil
+}
+
+// UnlockWorkspace unlocks a previously locked workspace.
+func (c *Client) UnlockWorkspace(ctx context.Context, workspaceID string) (Workspace, error) {
+	path := fmt.Sprintf("/workspaces/%s/actions/unlock", workspaceID)
+
+	type wrapper struct {
+		Data Workspace `json:"data"`
+	}
+
+	var resp wrapper
+	if err := c.do(ctx, "POST", path, nil, nil, &resp); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return Workspace{}, ErrWorkspaceNotFound
+		}
+		return Workspace{}, err
+	}
+	return resp.Data, nil
+}
+
+// StateVersionCreateOptions are the attributes required by the
+// state-versions POST endpoint. Serial, MD5, and State describe the state
+// file being uploaded. Use NewStateVersionCreateOptions to build one from a
+// raw state file; set the fields directly only if you already have the MD5
+// checksum and base64 encoding computed elsewhere.
+type StateVersionCreateOptions struct {
+	// Serial is the state file's serial number.
+	Serial int64 `json:"serial"`
+
+	// MD5 is the hex-encoded MD5 checksum of the raw (not base64-encoded)
+	// state file.
+	MD5 string `json:"md5"`
+
+	// Lineage is the state file's lineage identifier.
+	Lineage string `json:"lineage,omitempty"`
+
+	// State is the base64-encoded raw state payload.
+	State string `json:"state"`
+}
+
+// NewStateVersionCreateOptions builds StateVersionCreateOptions from a raw
+// (not base64-encoded) state file, computing the MD5 checksum and
+// base64-encoding State the way Terraform Enterprise expects on the wire.
+// This is the minimum a remote state backend needs to call
+// Client.CreateStateVersion without re-deriving that boilerplate itself.
+func NewStateVersionCreateOptions(raw []byte, serial int64, lineage string) StateVersionCreateOptions {
+	sum := md5.Sum(raw)
+	return StateVersionCreateOptions{
+		Serial:  serial,
+		MD5:     hex.EncodeToString(sum[:]),
+		Lineage: lineage,
+		State:   base64.StdEncoding.EncodeToString(raw),
+	}
+}
+
+// CreateStateVersion uploads a new state version for a workspace.
+func (c *Client) CreateStateVersion(ctx context.Context, workspaceID string, opts StateVersionCreateOptions) (StateVersion, error) {
+	body, err := newJSONAPIBody(jsonAPITypeStateVersions, opts)
+	if err != nil {
+		return StateVersion{}, err
+	}
+
+	path := fmt.Sprintf("/workspaces/%s/state-versions", workspaceID)
+
+	type wrapper struct {
+		Data StateVersion `json:"data"`
+	}
+
+	var resp wrapper
+	if err := c.do(ctx, "POST", path, body, nil, &resp); err != nil {
+		return StateVersion{}, err
+	}
+	return resp.Data, nil
+}
@@ -0,0 +1,111 @@
+package tfe
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"testing"
+)
+
+func TestNewJSONAPIBodyEncodesTypeAndAttributes(t *testing.T) {
+	body, err := newJSONAPIBody(jsonAPITypeWorkspaces, WorkspaceCreateOptions{Name: "my-workspace"})
+	if err != nil {
+		t.Fatalf("newJSONAPIBody: %v", err)
+	}
+
+	var decoded jsonAPIRequest
+	if err := json.NewDecoder(body).Decode(&decoded); err != nil {
+		t.Fatalf("decoding body: %v", err)
+	}
+
+	if decoded.Data.Type != jsonAPITypeWorkspaces {
+		t.Errorf("Data.Type = %q, want %q", decoded.Data.Type, jsonAPITypeWorkspaces)
+	}
+	if decoded.Data.Relationships != nil {
+		t.Errorf("Data.Relationships = %v, want nil", decoded.Data.Relationships)
+	}
+
+	attrs, ok := decoded.Data.Attributes.(map[string]interface{})
+	if !ok {
+		t.Fatalf("Data.Attributes = %T, want map[string]interface{}", decoded.Data.Attributes)
+	}
+	if attrs["name"] != "my-workspace" {
+		t.Errorf("Attributes[name] = %v, want %q", attrs["name"], "my-workspace")
+	}
+}
+
+func TestNewJSONAPIBodyOmitsEmptyAttributeFields(t *testing.T) {
+	body, err := newJSONAPIBody(jsonAPITypeWorkspaces, WorkspaceCreateOptions{Name: "my-workspace"})
+	if err != nil {
+		t.Fatalf("newJSONAPIBody: %v", err)
+	}
+
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("reading body: %v", err)
+	}
+
+	for _, field := range []string{"terraform-version", "working-directory", "auto-apply"} {
+		if containsField(raw, field) {
+			t.Errorf("body %s unexpectedly contains omitted field %q", raw, field)
+		}
+	}
+}
+
+func TestNewJSONAPIBodyWithRelationshipsEncodesRelationshipData(t *testing.T) {
+	body, err := newJSONAPIBodyWithRelationships(jsonAPITypeRuns, RunCreateOptions{}, map[string]jsonAPIRelationship{
+		"workspace": {Data: jsonAPIResourceID{Type: jsonAPITypeWorkspaces, ID: "ws-123"}},
+	})
+	if err != nil {
+		t.Fatalf("newJSONAPIBodyWithRelationships: %v", err)
+	}
+
+	var decoded jsonAPIRequest
+	if err := json.NewDecoder(body).Decode(&decoded); err != nil {
+		t.Fatalf("decoding body: %v", err)
+	}
+
+	rel, ok := decoded.Data.Relationships["workspace"]
+	if !ok {
+		t.Fatalf("Relationships[workspace] missing, got %v", decoded.Data.Relationships)
+	}
+	if rel.Data.Type != jsonAPITypeWorkspaces || rel.Data.ID != "ws-123" {
+		t.Errorf("Relationships[workspace].Data = %+v, want {Type:%q ID:%q}", rel.Data, jsonAPITypeWorkspaces, "ws-123")
+	}
+}
+
+func TestNewStateVersionCreateOptionsEncodesRawState(t *testing.T) {
+	raw := []byte(`{"version":4,"terraform_version":"1.6.0"}`)
+
+	opts := NewStateVersionCreateOptions(raw, 3, "lineage-abc")
+
+	if opts.Serial != 3 {
+		t.Errorf("Serial = %d, want 3", opts.Serial)
+	}
+	if opts.Lineage != "lineage-abc" {
+		t.Errorf("Lineage = %q, want %q", opts.Lineage, "lineage-abc")
+	}
+
+	sum := md5.Sum(raw)
+	if want := hex.EncodeToString(sum[:]); opts.MD5 != want {
+		t.Errorf("MD5 = %q, want %q", opts.MD5, want)
+	}
+	if want := base64.StdEncoding.EncodeToString(raw); opts.State != want {
+		t.Errorf("State = %q, want %q", opts.State, want)
+	}
+}
+
+func containsField(body []byte, field string) bool {
+	var decoded jsonAPIRequest
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return false
+	}
+	attrs, ok := decoded.Data.Attributes.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, present := attrs[field]
+	return present
+}